@@ -0,0 +1,185 @@
+// Copyright 2020 Snowfork
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package parachain
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.etcd.io/bbolt"
+)
+
+var commitmentsBucket = []byte("commitments")
+
+// CommitmentRecord is a single BEEFY commitment seen by the writer, keyed by
+// its parachain block number.
+type CommitmentRecord struct {
+	BlockNumber    uint64
+	ValidatorSetID uint64
+	CommitmentHash common.Hash
+}
+
+// CommitmentCache remembers commitments the writer has already processed, so
+// that a gap between the last completed commitment and a newly arrived one
+// can be detected before it is submitted on-chain. It is an in-memory index
+// backed optionally by a bbolt database so the cache survives a restart.
+//
+// order keeps the recorded block numbers sorted so the lowest/highest/
+// neighbouring records can be found without scanning every entry in byBlock.
+type CommitmentCache struct {
+	mu      sync.RWMutex
+	byBlock map[uint64]CommitmentRecord
+	order   []uint64
+	db      *bbolt.DB
+}
+
+// NewCommitmentCache creates a CommitmentCache. If dbPath is empty the cache
+// is purely in-memory and does not survive a restart.
+func NewCommitmentCache(dbPath string) (*CommitmentCache, error) {
+	cache := &CommitmentCache{byBlock: make(map[uint64]CommitmentRecord)}
+
+	if dbPath == "" {
+		return cache, nil
+	}
+
+	db, err := bbolt.Open(dbPath, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(commitmentsBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	cache.db = db
+
+	err = cache.loadFromDisk()
+	if err != nil {
+		return nil, err
+	}
+
+	return cache, nil
+}
+
+func (c *CommitmentCache) loadFromDisk() error {
+	err := c.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(commitmentsBucket).ForEach(func(_, v []byte) error {
+			var record CommitmentRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return err
+			}
+			c.byBlock[record.BlockNumber] = record
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	for blockNumber := range c.byBlock {
+		c.order = append(c.order, blockNumber)
+	}
+	sort.Slice(c.order, func(i, j int) bool { return c.order[i] < c.order[j] })
+	return nil
+}
+
+// Put records a commitment, persisting it to disk if the cache is disk-backed.
+func (c *CommitmentCache) Put(record CommitmentRecord) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.byBlock[record.BlockNumber]; !exists {
+		c.insertOrdered(record.BlockNumber)
+	}
+	c.byBlock[record.BlockNumber] = record
+
+	if c.db == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	return c.db.Update(func(tx *bbolt.Tx) error {
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, record.BlockNumber)
+		return tx.Bucket(commitmentsBucket).Put(key, data)
+	})
+}
+
+// insertOrdered inserts blockNumber into c.order, keeping it sorted. Callers
+// must hold c.mu.
+func (c *CommitmentCache) insertOrdered(blockNumber uint64) {
+	i := sort.Search(len(c.order), func(i int) bool { return c.order[i] >= blockNumber })
+	c.order = append(c.order, 0)
+	copy(c.order[i+1:], c.order[i:])
+	c.order[i] = blockNumber
+}
+
+// Get returns the commitment recorded for blockNumber, if any.
+func (c *CommitmentCache) Get(blockNumber uint64) (CommitmentRecord, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	record, ok := c.byBlock[blockNumber]
+	return record, ok
+}
+
+// Latest returns the highest-numbered commitment recorded so far.
+func (c *CommitmentCache) Latest() (CommitmentRecord, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if len(c.order) == 0 {
+		return CommitmentRecord{}, false
+	}
+	return c.byBlock[c.order[len(c.order)-1]], true
+}
+
+// Gap is the span between two commitments the cache has recorded back to
+// back, with nothing known to exist in between. Since BEEFY commitments are
+// periodic rather than one-per-block, the gap is reported as the boundary
+// block numbers themselves rather than every block number in between -
+// callers that need the actual missed commitments must fetch them, not
+// assume one exists at every block in the span.
+type Gap struct {
+	From uint64 // the last known commitment before the gap
+	To   uint64 // the next known commitment after the gap
+}
+
+// Gaps returns every pair of consecutively recorded commitments. It is the
+// caller's job to decide, using the real BEEFY commitment interval, whether a
+// given pair actually has a missed commitment between them - the cache has
+// no notion of the expected spacing. This is O(number of recorded
+// commitments), never O(block span), so it stays cheap no matter how far
+// apart two commitments land.
+func (c *CommitmentCache) Gaps() []Gap {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if len(c.order) < 2 {
+		return nil
+	}
+
+	gaps := make([]Gap, 0, len(c.order)-1)
+	for i := 1; i < len(c.order); i++ {
+		gaps = append(gaps, Gap{From: c.order[i-1], To: c.order[i]})
+	}
+	return gaps
+}
+
+// Close releases the underlying bbolt database, if any.
+func (c *CommitmentCache) Close() error {
+	if c.db == nil {
+		return nil
+	}
+	return c.db.Close()
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 Snowfork
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package parachain
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// clefClient talks to a clef instance over its external signer IPC/RPC API.
+// See https://geth.ethereum.org/docs/tools/clef/clef for account_signTransaction.
+type clefClient struct {
+	rpc *rpc.Client
+}
+
+func dialClef(ctx context.Context, endpoint string) (*clefClient, error) {
+	client, err := rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &clefClient{rpc: client}, nil
+}
+
+// clefSendTxArgs mirrors the shape clef expects for account_signTransaction.
+type clefSendTxArgs struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to,omitempty"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+	Value                *hexutil.Big    `json:"value"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	Data                 hexutil.Bytes   `json:"data"`
+	ChainID              *hexutil.Big    `json:"chainId"`
+}
+
+type clefSignTxResult struct {
+	Raw hexutil.Bytes `json:"raw"`
+}
+
+func (c *clefClient) SignTransaction(ctx context.Context, from common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := clefSendTxArgs{
+		From:                 from,
+		To:                   tx.To(),
+		Gas:                  hexutil.Uint64(tx.Gas()),
+		MaxFeePerGas:         (*hexutil.Big)(tx.GasFeeCap()),
+		MaxPriorityFeePerGas: (*hexutil.Big)(tx.GasTipCap()),
+		Value:                (*hexutil.Big)(tx.Value()),
+		Nonce:                hexutil.Uint64(tx.Nonce()),
+		Data:                 tx.Data(),
+		ChainID:              (*hexutil.Big)(chainID),
+	}
+
+	var result clefSignTxResult
+	err := c.rpc.CallContext(ctx, &result, "account_signTransaction", args)
+	if err != nil {
+		return nil, err
+	}
+
+	signedTx := new(types.Transaction)
+	err = signedTx.UnmarshalBinary(result.Raw)
+	if err != nil {
+		return nil, err
+	}
+	return signedTx, nil
+}
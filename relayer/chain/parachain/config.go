@@ -0,0 +1,54 @@
+// Copyright 2020 Snowfork
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package parachain
+
+// Config is the parachain writer's configuration, usually loaded from the
+// relayer's TOML config file under the "parachain" table.
+type Config struct {
+	Ethereum EthereumConfig
+}
+
+// EthereumConfig configures how the writer submits BEEFY commitments to
+// Ethereum: which contracts to call and which key signs the transactions.
+type EthereumConfig struct {
+	Contracts           ContractsConfig
+	Signer              SignerConfig
+	Drand               DrandConfig
+	Beefy               BeefyConfig
+	CommitmentCachePath string
+	Metrics             MetricsConfig
+}
+
+// MetricsConfig configures the writer's Prometheus metrics endpoint.
+type MetricsConfig struct {
+	// Address is the "host:port" the /metrics endpoint listens on. Metrics
+	// are disabled if empty.
+	Address string
+}
+
+// BeefyConfig tunes the reconciler's expectations about BEEFY commitment
+// spacing.
+type BeefyConfig struct {
+	// CommitmentInterval is the expected number of parachain blocks between
+	// consecutive BEEFY commitments. See NewReconciler.
+	CommitmentInterval uint64
+}
+
+// ContractsConfig holds the addresses of the Ethereum contracts the writer
+// submits to.
+type ContractsConfig struct {
+	RelayBridgeLightClient string
+}
+
+// SignerConfig selects and configures the TxSigner backend the writer uses
+// to sign submissions. See NewTxSigner.
+type SignerConfig struct {
+	// Type is one of SignerTypeLocal, SignerTypeClef or SignerTypeKMS. It
+	// defaults to SignerTypeLocal when empty.
+	Type string
+	// Endpoint is the clef or KMS endpoint to dial. Unused for SignerTypeLocal.
+	Endpoint string
+	// KeyID is the KMS key identifier to sign with. Unused outside SignerTypeKMS.
+	KeyID string
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 Snowfork
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package parachain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	drandclient "github.com/drand/drand/client"
+)
+
+// DrandConfig describes the drand beacon chain the writer pins random subset
+// selection to, configured under Config.Ethereum.Drand.
+type DrandConfig struct {
+	Enabled     bool
+	ChainHash   string
+	GenesisTime int64
+	Period      time.Duration
+	RelayURLs   []string
+}
+
+// drandMaxFetchRetries bounds how many times the writer tries an
+// unreachable drand network before failing closed: Select then returns an
+// error and no subset is derived, with no fallback to the contract's own
+// (block-hash-derived) randomness.
+const drandMaxFetchRetries = 3
+
+// DrandSubset holds the validator positions selected for a BEEFY random
+// signature subset, along with the drand round that produced them so it can
+// be attached as calldata and verified on-chain.
+type DrandSubset struct {
+	Positions []uint64
+	Round     uint64
+	Signature []byte
+}
+
+// DrandSubsetSelector derives RandomSignatureBitfieldPositions from a
+// verifiable drand beacon round instead of the LightClientBridge contract's
+// on-chain blockhash randomness, which validators can bias by withholding or
+// timing their block proposals.
+type DrandSubsetSelector struct {
+	client      drandclient.Client
+	genesisTime int64
+	period      time.Duration
+}
+
+// NewDrandSubsetSelector dials the drand chain described by config.
+func NewDrandSubsetSelector(ctx context.Context, config DrandConfig) (*DrandSubsetSelector, error) {
+	c, err := drandclient.New(
+		drandclient.WithHTTPEndpoints(config.RelayURLs),
+		drandclient.WithChainHash(common.FromHex(config.ChainHash)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("dialing drand: %w", err)
+	}
+
+	return &DrandSubsetSelector{
+		client:      c,
+		genesisTime: config.GenesisTime,
+		period:      config.Period,
+	}, nil
+}
+
+// Select derives k unique validator positions out of validatorCount for the
+// commitment identified by commitmentHash, pinned to the drand round covering
+// blockTimestamp. It fails closed if drand cannot be reached after
+// drandMaxFetchRetries attempts.
+func (s *DrandSubsetSelector) Select(ctx context.Context, blockTimestamp int64, commitmentHash common.Hash, validatorCount int, k int) (DrandSubset, error) {
+	if validatorCount <= 0 {
+		return DrandSubset{}, fmt.Errorf("validatorCount must be positive, got %d", validatorCount)
+	}
+
+	round := uint64((blockTimestamp-s.genesisTime)/int64(s.period.Seconds())) + 1
+
+	var lastErr error
+	for attempt := 0; attempt < drandMaxFetchRetries; attempt++ {
+		result, err := s.client.Get(ctx, round)
+		if err == nil {
+			positions := derivePositions(result.Randomness(), commitmentHash, validatorCount, k)
+			return DrandSubset{
+				Positions: positions,
+				Round:     result.Round(),
+				Signature: result.Signature(),
+			}, nil
+		}
+		lastErr = err
+	}
+
+	return DrandSubset{}, fmt.Errorf("fetching drand round %d after %d attempts: %w", round, drandMaxFetchRetries, lastErr)
+}
+
+// derivePositions iteratively hashes drandRandomness with commitmentHash and a
+// counter, taking the result mod validatorCount, until k unique indices have
+// been chosen. Already-picked indices are skipped rather than retried with
+// fresh randomness, so the sequence of draws is reproducible from the same
+// inputs.
+func derivePositions(drandRandomness []byte, commitmentHash common.Hash, validatorCount int, k int) []uint64 {
+	seen := make(map[uint64]bool, k)
+	positions := make([]uint64, 0, k)
+
+	for counter := uint64(0); len(positions) < k; counter++ {
+		h := sha256.New()
+		h.Write(drandRandomness)
+		h.Write(commitmentHash.Bytes())
+		var counterBytes [8]byte
+		binary.BigEndian.PutUint64(counterBytes[:], counter)
+		h.Write(counterBytes[:])
+
+		digest := new(big.Int).SetBytes(h.Sum(nil))
+		index := new(big.Int).Mod(digest, big.NewInt(int64(validatorCount))).Uint64()
+
+		if seen[index] {
+			continue
+		}
+		seen[index] = true
+		positions = append(positions, index)
+	}
+
+	return positions
+}
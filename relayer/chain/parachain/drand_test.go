@@ -0,0 +1,70 @@
+// Copyright 2020 Snowfork
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package parachain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestDerivePositionsDeterministicAndUnique(t *testing.T) {
+	randomness := []byte("drand-round-randomness")
+	commitmentHash := common.HexToHash("0x1234")
+
+	first := derivePositions(randomness, commitmentHash, 100, 10)
+	second := derivePositions(randomness, commitmentHash, 100, 10)
+
+	if len(first) != 10 {
+		t.Fatalf("expected 10 positions, got %d", len(first))
+	}
+
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("expected derivePositions to be deterministic, got %v and %v", first, second)
+		}
+	}
+
+	seen := make(map[uint64]bool, len(first))
+	for _, pos := range first {
+		if seen[pos] {
+			t.Fatalf("expected unique positions, got duplicate %d in %v", pos, first)
+		}
+		if pos >= 100 {
+			t.Fatalf("expected position below validator count 100, got %d", pos)
+		}
+		seen[pos] = true
+	}
+}
+
+func TestDerivePositionsDiffersByCommitmentHash(t *testing.T) {
+	randomness := []byte("drand-round-randomness")
+
+	a := derivePositions(randomness, common.HexToHash("0x1"), 100, 10)
+	b := derivePositions(randomness, common.HexToHash("0x2"), 100, 10)
+
+	match := true
+	for i := range a {
+		if a[i] != b[i] {
+			match = false
+			break
+		}
+	}
+	if match {
+		t.Fatalf("expected different commitment hashes to derive different positions, got %v for both", a)
+	}
+}
+
+func TestSelectRejectsNonPositiveValidatorCount(t *testing.T) {
+	selector := &DrandSubsetSelector{}
+
+	if _, err := selector.Select(context.Background(), 0, common.HexToHash("0x1"), 0, 10); err == nil {
+		t.Fatal("expected an error for a zero validator count, got nil")
+	}
+
+	if _, err := selector.Select(context.Background(), 0, common.HexToHash("0x1"), -1, 10); err == nil {
+		t.Fatal("expected an error for a negative validator count, got nil")
+	}
+}
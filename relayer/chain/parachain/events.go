@@ -0,0 +1,65 @@
+// Copyright 2020 Snowfork
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package parachain
+
+import "github.com/ethereum/go-ethereum/common"
+
+// WriterEventKind identifies the stage a WriterEvent describes.
+type WriterEventKind string
+
+const (
+	EventNewCommitmentSubmitted      WriterEventKind = "new_commitment_submitted"
+	EventCommitmentCompleteSubmitted WriterEventKind = "commitment_complete_submitted"
+	EventCommitmentConfirmed         WriterEventKind = "commitment_confirmed"
+	EventCommitmentReverted          WriterEventKind = "commitment_reverted"
+)
+
+// WriterEvent is a single, typed state transition emitted by the writer, so
+// that higher-level workers and tests can observe submission progress without
+// inferring it from logs or the BeefyCommitmentInfo status bump.
+type WriterEvent struct {
+	Kind        WriterEventKind
+	BlockNumber uint64
+	TxHash      common.Hash
+	Err         error
+}
+
+// Events returns the channel WriterEvents are published on. It is closed when
+// the writer shuts down.
+func (wr *Writer) Events() <-chan WriterEvent {
+	return wr.events
+}
+
+// emit publishes an event without blocking the writer if nobody is listening.
+// It holds eventsMu for reading so it cannot race with closeEvents: either it
+// observes eventsClosed and drops the event, or it completes its send before
+// the channel is closed.
+func (wr *Writer) emit(event WriterEvent) {
+	wr.eventsMu.RLock()
+	defer wr.eventsMu.RUnlock()
+
+	if wr.eventsClosed {
+		return
+	}
+
+	select {
+	case wr.events <- event:
+	default:
+		wr.log.WithField("kind", event.Kind).Debug("Dropped writer event, no listener")
+	}
+}
+
+// closeEvents closes the events channel exactly once, synchronized against
+// emit so a worker still mid-flight at shutdown can never send on a closed
+// channel.
+func (wr *Writer) closeEvents() {
+	wr.eventsMu.Lock()
+	defer wr.eventsMu.Unlock()
+
+	if wr.eventsClosed {
+		return
+	}
+	wr.eventsClosed = true
+	close(wr.events)
+}
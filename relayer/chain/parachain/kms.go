@@ -0,0 +1,135 @@
+// Copyright 2020 Snowfork
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package parachain
+
+import (
+	"context"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+// kmsClient signs digests using an asymmetric ECDSA_SECP256K1 key held in AWS
+// KMS (or a compatible HashiCorp Vault transit endpoint speaking the same
+// GetPublicKey/Sign API shape). The private key material never leaves the KMS.
+type kmsClient struct {
+	svc     *kms.KMS
+	keyID   string
+	address common.Address
+}
+
+func dialKMS(endpoint string, keyID string) (*kmsClient, error) {
+	sess, err := session.NewSession(&aws.Config{Endpoint: aws.String(endpoint)})
+	if err != nil {
+		return nil, err
+	}
+	svc := kms.New(sess)
+
+	pub, err := svc.GetPublicKey(&kms.GetPublicKeyInput{KeyId: aws.String(keyID)})
+	if err != nil {
+		return nil, fmt.Errorf("fetching KMS public key: %w", err)
+	}
+
+	pubKey, err := crypto.UnmarshalPubkey(pub.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("decoding KMS public key: %w", err)
+	}
+
+	return &kmsClient{
+		svc:     svc,
+		keyID:   keyID,
+		address: crypto.PubkeyToAddress(*pubKey),
+	}, nil
+}
+
+func (c *kmsClient) Address() common.Address {
+	return c.address
+}
+
+// SignDigest signs a 32-byte transaction hash and returns a 65-byte recoverable
+// signature (r || s || v) suitable for types.Transaction.WithSignature.
+func (c *kmsClient) SignDigest(ctx context.Context, digest []byte) ([]byte, error) {
+	out, err := c.svc.SignWithContext(ctx, &kms.SignInput{
+		KeyId:            aws.String(c.keyID),
+		Message:          digest,
+		MessageType:      aws.String(kms.MessageTypeDigest),
+		SigningAlgorithm: aws.String(kms.SigningAlgorithmSpecEcdsaSha256),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recoverableSignature(digest, out.Signature, c.address)
+}
+
+// secp256k1N is the order of the secp256k1 curve group, and secp256k1halfN is
+// half of it. go-ethereum's crypto.ValidateSignatureValues (enforced on every
+// transaction post-Homestead) rejects any signature with s > secp256k1halfN,
+// so KMS's S value must be normalized into the lower half before use.
+var (
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1halfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// recoverableSignature converts a DER-encoded ECDSA signature from KMS into the
+// (r, s, v) form Ethereum expects, trying both recovery ids since KMS does not
+// return one directly. KMS does not guarantee a low-S signature, so s is
+// normalized to the curve's lower half before recovery is attempted, matching
+// the signatures crypto.Sign produces; trying both recovery ids then finds
+// the one that fits the normalized s.
+func recoverableSignature(digest, derSig []byte, expected common.Address) ([]byte, error) {
+	r, s, err := unmarshalDERSignature(derSig)
+	if err != nil {
+		return nil, err
+	}
+
+	sBig := new(big.Int).SetBytes(s)
+	if sBig.Cmp(secp256k1halfN) > 0 {
+		sBig = new(big.Int).Sub(secp256k1N, sBig)
+	}
+
+	sig := make([]byte, 65)
+	copy(sig[0:32], padTo32(r))
+	copy(sig[32:64], padTo32(sBig.Bytes()))
+
+	for _, v := range []byte{0, 1} {
+		sig[64] = v
+		pub, err := crypto.SigToPub(digest, sig)
+		if err == nil && crypto.PubkeyToAddress(*pub) == expected {
+			return sig, nil
+		}
+	}
+
+	return nil, fmt.Errorf("could not recover signature to expected address %s", expected.Hex())
+}
+
+func padTo32(b []byte) []byte {
+	if len(b) >= 32 {
+		return b[len(b)-32:]
+	}
+	out := make([]byte, 32)
+	copy(out[32-len(b):], b)
+	return out
+}
+
+// unmarshalDERSignature decodes the ASN.1 DER (r, s) pair KMS returns for an
+// ECDSA_SHA_256 signature.
+func unmarshalDERSignature(der []byte) (r, s []byte, err error) {
+	var sig struct {
+		R *big.Int
+		S *big.Int
+	}
+	_, err = asn1.Unmarshal(der, &sig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sig.R.Bytes(), sig.S.Bytes(), nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 Snowfork
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package parachain
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestPadTo32(t *testing.T) {
+	cases := []struct {
+		in  []byte
+		out []byte
+	}{
+		{in: []byte{1, 2, 3}, out: append(make([]byte, 29), 1, 2, 3)},
+		{in: make([]byte, 32), out: make([]byte, 32)},
+		{in: append([]byte{0xff}, make([]byte, 32)...), out: append([]byte{0xff}, make([]byte, 32)...)[1:]},
+	}
+
+	for i, c := range cases {
+		got := padTo32(c.in)
+		if !bytes.Equal(got, c.out) {
+			t.Fatalf("case %d: expected %x, got %x", i, c.out, got)
+		}
+		if len(got) != 32 {
+			t.Fatalf("case %d: expected 32 bytes, got %d", i, len(got))
+		}
+	}
+}
+
+func TestRecoverableSignatureNormalizesHighS(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	digest := make([]byte, 32)
+	if _, err := rand.Read(digest); err != nil {
+		t.Fatalf("generating digest: %v", err)
+	}
+
+	refSig, err := crypto.Sign(digest, key)
+	if err != nil {
+		t.Fatalf("signing digest: %v", err)
+	}
+	r := refSig[0:32]
+	s := new(big.Int).SetBytes(refSig[32:64])
+
+	// Flip s into the upper half of the curve order, as a KMS backend that
+	// does not normalize to low-S might return, and check that the DER
+	// encoding built from it still recovers to the expected address.
+	highS := new(big.Int).Sub(secp256k1N, s)
+	if highS.Cmp(secp256k1halfN) <= 0 {
+		t.Fatalf("expected negated s to land in the upper half of the curve order")
+	}
+
+	der, err := asn1.Marshal(struct {
+		R *big.Int
+		S *big.Int
+	}{R: new(big.Int).SetBytes(r), S: highS})
+	if err != nil {
+		t.Fatalf("marshaling DER signature: %v", err)
+	}
+
+	sig, err := recoverableSignature(digest, der, address)
+	if err != nil {
+		t.Fatalf("recoverableSignature returned error: %v", err)
+	}
+
+	if new(big.Int).SetBytes(sig[32:64]).Cmp(secp256k1halfN) > 0 {
+		t.Fatalf("expected normalized signature to have low s, got %x", sig[32:64])
+	}
+
+	pub, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		t.Fatalf("recovering public key: %v", err)
+	}
+	if crypto.PubkeyToAddress(*pub) != address {
+		t.Fatalf("recovered address does not match expected signer")
+	}
+}
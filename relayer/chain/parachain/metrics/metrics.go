@@ -0,0 +1,71 @@
+// Copyright 2020 Snowfork
+// SPDX-License-Identifier: LGPL-3.0-only
+
+// Package metrics exposes Prometheus instrumentation for the parachain writer.
+package metrics
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	// CommitmentsSubmitted counts BEEFY commitment transactions submitted to
+	// Ethereum and mined without reverting. Reverted transactions are counted
+	// by TxReverted instead.
+	CommitmentsSubmitted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "beefy_commitments_submitted_total",
+		Help: "Number of BEEFY commitment transactions submitted to Ethereum and mined without reverting.",
+	}, []string{"stage"})
+
+	// SubmissionSeconds measures the latency of a commitment submission, from
+	// build through mined receipt.
+	SubmissionSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "beefy_commitment_submission_seconds",
+		Help:    "Time taken to submit and confirm a BEEFY commitment transaction.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"stage"})
+
+	// TxReverted counts transactions that were mined but reverted, by
+	// submission stage. There is no decoded revert reason available at the
+	// call site, so this only distinguishes which kind of submission
+	// reverted, not why.
+	TxReverted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "beefy_tx_reverted_total",
+		Help: "Number of BEEFY commitment transactions that reverted on-chain, by submission stage.",
+	}, []string{"stage"})
+
+	// MessagesChannelDepth reports the current depth of the writer's inbound messages channel.
+	MessagesChannelDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "beefy_messages_channel_depth",
+		Help: "Number of message batches currently queued for the writer.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(CommitmentsSubmitted, SubmissionSeconds, TxReverted, MessagesChannelDepth)
+}
+
+// Serve starts a blocking HTTP server exposing the registered metrics at
+// /metrics on addr (e.g. ":9090"). It returns when ctx is cancelled.
+func Serve(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	server := &http.Server{Addr: addr, Handler: mux}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return server.Shutdown(context.Background())
+	case err := <-errCh:
+		return err
+	}
+}
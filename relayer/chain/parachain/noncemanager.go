@@ -0,0 +1,96 @@
+// Copyright 2020 Snowfork
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package parachain
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/snowfork/polkadot-ethereum/relayer/chain/ethereum"
+)
+
+// nonceHeap is a min-heap of nonces released back to the pool, so the lowest
+// released nonce is always handed out next regardless of release order.
+type nonceHeap []uint64
+
+func (h nonceHeap) Len() int            { return len(h) }
+func (h nonceHeap) Less(i, j int) bool  { return h[i] < h[j] }
+func (h nonceHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *nonceHeap) Push(x interface{}) { *h = append(*h, x.(uint64)) }
+func (h *nonceHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// NonceManager hands out sequential nonces for a set of sender addresses so that
+// concurrent transaction submissions never collide or leave gaps. It is seeded
+// lazily from the chain's pending nonce the first time a sender is seen.
+//
+// Because workers reserve nonces concurrently, a nonce can be released out of
+// order (e.g. the worker holding nonce N fails after a worker holding nonce
+// N+1 has already reserved its own nonce). Released nonces are kept in a
+// min-heap per sender and handed out again before minting a new one, so no
+// nonce is ever silently lost.
+type NonceManager struct {
+	mu   sync.Mutex
+	conn *ethereum.Connection
+	next map[common.Address]uint64
+	free map[common.Address]*nonceHeap
+}
+
+// NewNonceManager creates a NonceManager backed by the given Ethereum connection.
+func NewNonceManager(conn *ethereum.Connection) *NonceManager {
+	return &NonceManager{
+		conn: conn,
+		next: make(map[common.Address]uint64),
+		free: make(map[common.Address]*nonceHeap),
+	}
+}
+
+// Reserve returns the next nonce to use for sender and advances its internal
+// counter. A previously released nonce is handed out first, if any. The
+// first reservation for a given sender is seeded from the account's current
+// pending nonce on-chain.
+func (nm *NonceManager) Reserve(ctx context.Context, sender common.Address) (uint64, error) {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+
+	if freed := nm.free[sender]; freed != nil && freed.Len() > 0 {
+		return heap.Pop(freed).(uint64), nil
+	}
+
+	next, ok := nm.next[sender]
+	if !ok {
+		pending, err := nm.conn.GetClient().PendingNonceAt(ctx, sender)
+		if err != nil {
+			return 0, err
+		}
+		next = pending
+	}
+
+	nm.next[sender] = next + 1
+	return next, nil
+}
+
+// Release gives a reserved nonce back, for use when a submission fails before
+// a transaction is ever broadcast and the nonce would otherwise be wasted. It
+// is safe to call for any previously reserved nonce, not just the most
+// recently reserved one.
+func (nm *NonceManager) Release(sender common.Address, nonce uint64) {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+
+	freed := nm.free[sender]
+	if freed == nil {
+		freed = &nonceHeap{}
+		nm.free[sender] = freed
+	}
+	heap.Push(freed, nonce)
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 Snowfork
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package parachain
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestNonceManagerReserveIncrements(t *testing.T) {
+	nm := NewNonceManager(nil)
+	sender := common.HexToAddress("0x1")
+	nm.next[sender] = 5
+
+	first, err := nm.Reserve(nil, sender)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first != 5 {
+		t.Fatalf("expected nonce 5, got %d", first)
+	}
+
+	second, err := nm.Reserve(nil, sender)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second != 6 {
+		t.Fatalf("expected nonce 6, got %d", second)
+	}
+}
+
+func TestNonceManagerReleaseOutOfOrder(t *testing.T) {
+	nm := NewNonceManager(nil)
+	sender := common.HexToAddress("0x1")
+	nm.next[sender] = 10
+
+	a, _ := nm.Reserve(nil, sender) // 10
+	b, _ := nm.Reserve(nil, sender) // 11
+	c, _ := nm.Reserve(nil, sender) // 12
+
+	// Release the earliest nonce last, simulating a worker holding nonce 10
+	// failing after workers holding 11 and 12 have already reserved theirs.
+	nm.Release(sender, b)
+	nm.Release(sender, a)
+
+	first, _ := nm.Reserve(nil, sender)
+	if first != a {
+		t.Fatalf("expected released nonce %d to be reused first, got %d", a, first)
+	}
+
+	second, _ := nm.Reserve(nil, sender)
+	if second != b {
+		t.Fatalf("expected released nonce %d to be reused next, got %d", b, second)
+	}
+
+	third, _ := nm.Reserve(nil, sender)
+	if third != c+1 {
+		t.Fatalf("expected a fresh nonce %d once the free list is drained, got %d", c+1, third)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2020 Snowfork
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package parachain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/snowfork/polkadot-ethereum/relayer/chain/ethereum"
+	"github.com/snowfork/polkadot-ethereum/relayer/parachain"
+)
+
+// reconcileScanInterval is how often the background reconciler checks the
+// commitment cache for gaps left by a crash or a missed message.
+const reconcileScanInterval = 1 * time.Minute
+
+// Reconciler proves continuity between BEEFY commitments before they are
+// submitted to the LightClientBridge contract. Every ReadyToComplete
+// commitment must be preceded by proof that no intermediate commitment was
+// skipped since the last one the writer submitted.
+//
+// Real continuity proof requires fetching the intermediate MMR leaves from
+// the parachain and regenerating their merkle proofs against the current
+// on-chain MMR root - infrastructure this reconciler does not have access to
+// yet. Until that exists, a detected gap fails closed rather than being
+// recorded as reconciled on trust, per commitmentInterval below.
+type Reconciler struct {
+	cache              *CommitmentCache
+	conn               *ethereum.Connection
+	log                *logrus.Entry
+	commitmentInterval uint64
+}
+
+// NewReconciler creates a Reconciler backed by cache. commitmentInterval is
+// the expected number of parachain blocks between consecutive BEEFY
+// commitments, used to tell an expected gap (no commitment due yet) apart
+// from a missed one.
+func NewReconciler(cache *CommitmentCache, conn *ethereum.Connection, log *logrus.Entry, commitmentInterval uint64) *Reconciler {
+	return &Reconciler{cache: cache, conn: conn, log: log, commitmentInterval: commitmentInterval}
+}
+
+// EnsureContinuity detects a gap between the last commitment recorded in the
+// cache and beefyInfo. A gap only exists if more than one commitment
+// interval's worth of blocks separates them - anything less is the normal
+// spacing between periodic BEEFY commitments, not a missed one.
+//
+// There is currently no way to prove a detected gap was actually backfilled
+// (see Reconciler doc comment), so EnsureContinuity fails closed: it returns
+// an error rather than fabricating a record that claims the gap was
+// reconciled.
+func (r *Reconciler) EnsureContinuity(ctx context.Context, beefyInfo parachain.BeefyCommitmentInfo) error {
+	latest, ok := r.cache.Latest()
+	if !ok || beefyInfo.BlockNumber <= latest.BlockNumber+r.commitmentInterval {
+		return nil
+	}
+
+	return r.backfill(ctx, latest.BlockNumber, beefyInfo.BlockNumber)
+}
+
+// backfill would fetch the MMR leaves for the commitments expected between
+// from and to, regenerate their merkle proofs against the current MMR root,
+// and record them in the cache to prove continuity. That fetch is not wired
+// up yet, so backfill fails closed instead of recording an unproven gap as
+// reconciled.
+func (r *Reconciler) backfill(ctx context.Context, from, to uint64) error {
+	// TODO: fetch the MMR leaves for the commitments expected between from
+	// and to from the parachain and regenerate their merkle proofs against
+	// the latest on-chain MMR root, then record each as a CommitmentRecord.
+	return fmt.Errorf("commitment continuity gap between blocks %d and %d is unproven: backfill is not implemented", from, to)
+}
+
+// ForceAdvance lets an operator manually re-seed the cache past a gap that
+// backfill cannot prove, so the writer does not refuse every ReadyToComplete
+// commitment forever after a single missed one. It bypasses continuity proof
+// entirely - the operator is vouching that record is safe to treat as the
+// new baseline, e.g. after confirming out of band that nothing was actually
+// missed. Every call is logged at Warn level since it trades proof for
+// liveness.
+func (r *Reconciler) ForceAdvance(record CommitmentRecord) error {
+	r.log.WithFields(logrus.Fields{
+		"blockNumber":    record.BlockNumber,
+		"validatorSetID": record.ValidatorSetID,
+	}).Warn("Operator forced the commitment cache past an unproven gap")
+
+	return r.cache.Put(record)
+}
+
+// Start runs a periodic scan for cache gaps in the background, catching any
+// commitment the writer missed entirely (e.g. due to a restart between the
+// gap and the commitment that would have revealed it). It only logs gaps
+// wider than commitmentInterval, and does so once per gap rather than once
+// per missed block.
+func (r *Reconciler) Start(ctx context.Context) {
+	ticker := time.NewTicker(reconcileScanInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, gap := range r.cache.Gaps() {
+				if gap.To <= gap.From+r.commitmentInterval {
+					continue
+				}
+				if err := r.backfill(ctx, gap.From, gap.To); err != nil {
+					r.log.WithError(err).WithFields(logrus.Fields{
+						"from": gap.From,
+						"to":   gap.To,
+					}).Warn("Periodic reconciliation scan found an unproven gap in BEEFY commitments")
+				}
+			}
+		}
+	}
+}
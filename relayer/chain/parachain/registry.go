@@ -0,0 +1,88 @@
+// Copyright 2020 Snowfork
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package parachain
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/snowfork/polkadot-ethereum/relayer/chain"
+	"github.com/snowfork/polkadot-ethereum/relayer/parachain"
+)
+
+// ContractRegistry holds the set of destination contracts a Writer can submit
+// to, keyed by a short contract ID (e.g. LightClientBridgeContractID). This
+// replaces a single hardcoded contract field so that future contracts such as
+// IncentivizedInboundChannel or BasicInboundChannel can be added without
+// changing Writer itself.
+type ContractRegistry struct {
+	mu        sync.RWMutex
+	contracts map[string]interface{}
+}
+
+// NewContractRegistry creates an empty ContractRegistry.
+func NewContractRegistry() *ContractRegistry {
+	return &ContractRegistry{
+		contracts: make(map[string]interface{}),
+	}
+}
+
+// Register adds or replaces the contract bound to id.
+func (r *ContractRegistry) Register(id string, contract interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.contracts[id] = contract
+}
+
+// Get returns the contract bound to id, if any.
+func (r *ContractRegistry) Get(id string) (interface{}, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	contract, ok := r.contracts[id]
+	return contract, ok
+}
+
+// MessageHandler submits a single chain.Message to the contract resolved for
+// it from the ContractRegistry.
+type MessageHandler func(ctx context.Context, contract interface{}, msg chain.Message) error
+
+// messageRoute pairs the handler registered for a message type with the
+// contract ID it submits to. Keeping the contract ID here rather than inside
+// the handler itself is what lets a new destination contract (e.g.
+// IncentivizedInboundChannel) be added purely by registering a route - no
+// dispatch code in Writer needs to change.
+type messageRoute struct {
+	contractID string
+	handler    MessageHandler
+}
+
+// defaultMessageRoutes returns the routes a Writer installs when the caller
+// does not supply its own, preserving prior behaviour for
+// parachain.BeefyCommitmentInfo messages.
+func (wr *Writer) defaultMessageRoutes() map[reflect.Type]messageRoute {
+	return map[reflect.Type]messageRoute{
+		reflect.TypeOf(parachain.BeefyCommitmentInfo{}): {
+			contractID: LightClientBridgeContractID,
+			handler:    wr.handleBeefyMessage,
+		},
+	}
+}
+
+// routeFor looks up the messageRoute registered for msg's concrete type and
+// resolves its contract from the registry.
+func (wr *Writer) routeFor(msg chain.Message) (interface{}, MessageHandler, error) {
+	route, ok := wr.routes[reflect.TypeOf(msg)]
+	if !ok {
+		return nil, nil, fmt.Errorf("No handler registered for message type %T", msg)
+	}
+
+	contract, ok := wr.contracts.Get(route.contractID)
+	if !ok {
+		return nil, nil, fmt.Errorf("No contract registered for id %s", route.contractID)
+	}
+
+	return contract, route.handler, nil
+}
@@ -8,41 +8,141 @@ package parachain
 import (
 	"context"
 	"fmt"
+	"math/big"
+	"reflect"
+	"sync"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 
 	"github.com/snowfork/polkadot-ethereum/relayer/chain"
 	"github.com/snowfork/polkadot-ethereum/relayer/chain/ethereum"
+	"github.com/snowfork/polkadot-ethereum/relayer/chain/parachain/metrics"
 	"github.com/snowfork/polkadot-ethereum/relayer/contracts/lightclientbridge"
 	"github.com/snowfork/polkadot-ethereum/relayer/parachain"
 )
 
 const LightClientBridgeContractID = "lightclientbridge"
 
+// WorkerPoolConfig tunes how WriteNewSignatureCommitment and
+// WriteCompleteSignatureCommitment submissions are fanned out across workers.
+type WorkerPoolConfig struct {
+	// Workers is the number of goroutines draining the messages channel concurrently.
+	Workers int
+	// MaxInFlight bounds the number of unconfirmed transactions outstanding at once.
+	MaxInFlight int
+	// GasTipCap is the starting EIP-1559 priority fee offered to the network.
+	GasTipCap *big.Int
+	// GasFeeCap is the starting EIP-1559 maximum total fee offered to the network.
+	GasFeeCap *big.Int
+	// RetryBackoff is how long to wait for a transaction to be mined before it
+	// is considered stuck and resubmitted with a bumped gas price.
+	RetryBackoff time.Duration
+}
+
+// defaultWorkerPoolConfig is used when the caller does not supply one, preserving
+// the previous serial, single-submission behaviour.
+func defaultWorkerPoolConfig() WorkerPoolConfig {
+	return WorkerPoolConfig{
+		Workers:      1,
+		MaxInFlight:  1,
+		GasTipCap:    big.NewInt(1500000000), // 1.5 gwei
+		GasFeeCap:    big.NewInt(30000000000), // 30 gwei
+		RetryBackoff: 30 * time.Second,
+	}
+}
+
 type Writer struct {
 	config    *Config
 	conn      *ethereum.Connection
-	contracts map[string]*lightclientbridge.Contract
+	contracts *ContractRegistry
+	routes    map[reflect.Type]messageRoute
 	messages  <-chan []chain.Message
 	beefy     chan parachain.BeefyCommitmentInfo
 	log       *logrus.Entry
+
+	pool    WorkerPoolConfig
+	nonces  *NonceManager
+	signer  TxSigner
+	chainID *big.Int
+
+	cache      *CommitmentCache
+	reconciler *Reconciler
+	drand      *DrandSubsetSelector
+
+	events       chan WriterEvent
+	eventsMu     sync.RWMutex
+	eventsClosed bool
+
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	inFlight chan struct{}
 }
 
 func NewWriter(config *Config, conn *ethereum.Connection, messages <-chan []chain.Message, beefy chan parachain.BeefyCommitmentInfo,
-	contracts map[string]*lightclientbridge.Contract, log *logrus.Entry) (*Writer, error) {
-	return &Writer{
+	contracts *ContractRegistry, log *logrus.Entry) (*Writer, error) {
+	signer, err := NewTxSigner(context.Background(), config, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	cache, err := NewCommitmentCache(config.Ethereum.CommitmentCachePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var drand *DrandSubsetSelector
+	if config.Ethereum.Drand.Enabled {
+		drand, err = NewDrandSubsetSelector(context.Background(), config.Ethereum.Drand)
+		if err != nil {
+			return nil, fmt.Errorf("configuring drand subset selector: %w", err)
+		}
+	}
+
+	wr := &Writer{
 		config:    config,
 		conn:      conn,
 		contracts: contracts,
 		messages:  messages,
 		beefy:     beefy,
 		log:       log,
-	}, nil
+		pool:      defaultWorkerPoolConfig(),
+		nonces:    NewNonceManager(conn),
+		signer:    signer,
+		cache:     cache,
+		drand:     drand,
+		events:    make(chan WriterEvent, 64),
+	}
+	wr.routes = wr.defaultMessageRoutes()
+	wr.reconciler = NewReconciler(cache, conn, log, config.Ethereum.Beefy.CommitmentInterval)
+
+	return wr, nil
+}
+
+// NewWriterWithWorkerPool is like NewWriter but allows the caller to configure
+// the concurrent submission subsystem explicitly.
+func NewWriterWithWorkerPool(config *Config, conn *ethereum.Connection, messages <-chan []chain.Message, beefy chan parachain.BeefyCommitmentInfo,
+	contracts *ContractRegistry, log *logrus.Entry, pool WorkerPoolConfig) (*Writer, error) {
+	wr, err := NewWriter(config, conn, messages, beefy, contracts, log)
+	if err != nil {
+		return nil, err
+	}
+	wr.pool = pool
+	return wr, nil
+}
+
+// RegisterHandler associates a MessageHandler and its destination contract ID
+// with messages of msgType's concrete type, so a new destination contract
+// (registered separately via wr.contracts.Register) can be wired up without
+// modifying writeLoop or any existing handler.
+func (wr *Writer) RegisterHandler(msgType reflect.Type, contractID string, handler MessageHandler) {
+	wr.routes[msgType] = messageRoute{contractID: contractID, handler: handler}
 }
 
 func (wr *Writer) Start(ctx context.Context, eg *errgroup.Group) error {
@@ -51,21 +151,84 @@ func (wr *Writer) Start(ctx context.Context, eg *errgroup.Group) error {
 	if err != nil {
 		return err
 	}
-	wr.contracts[LightClientBridgeContractID] = contract
+	wr.contracts.Register(LightClientBridgeContractID, contract)
+
+	chainID, err := wr.conn.GetClient().ChainID(ctx)
+	if err != nil {
+		return err
+	}
+	wr.chainID = chainID
+
+	workCtx, cancel := context.WithCancel(ctx)
+	wr.cancel = cancel
+
+	maxInFlight := wr.pool.MaxInFlight
+	if maxInFlight < 1 {
+		maxInFlight = 1
+	}
+	wr.inFlight = make(chan struct{}, maxInFlight)
+
+	workers := wr.pool.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	for i := 0; i < workers; i++ {
+		wr.wg.Add(1)
+		eg.Go(func() error {
+			defer wr.wg.Done()
+			return wr.writeLoop(workCtx)
+		})
+	}
 
 	eg.Go(func() error {
-		return wr.writeLoop(ctx)
+		wr.reconciler.Start(workCtx)
+		return nil
+	})
+
+	if wr.config.Ethereum.Metrics.Address != "" {
+		eg.Go(func() error {
+			return metrics.Serve(workCtx, wr.config.Ethereum.Metrics.Address)
+		})
+	}
+
+	eg.Go(func() error {
+		return wr.onDone(ctx)
 	})
 
 	return nil
 }
 
+// onDone waits for ctx to be cancelled, then cancels any in-flight
+// submissions and drains the messages channel so that upstream listeners do
+// not deadlock while the writer shuts down. It runs once, independent of the
+// worker pool, so waiting for the workers to unwind can never deadlock on
+// itself.
 func (wr *Writer) onDone(ctx context.Context) error {
+	<-ctx.Done()
+
 	wr.log.Info("Shutting down writer...")
+	wr.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		wr.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(wr.pool.RetryBackoff + 5*time.Second):
+		wr.log.Warn("Timed out waiting for in-flight submissions to unwind")
+	}
+
 	// Avoid deadlock if a listener is still trying to send to a channel
 	for range wr.messages {
 		wr.log.Debug("Discarded message")
 	}
+
+	wr.closeEvents()
+
 	return ctx.Err()
 }
 
@@ -73,45 +236,132 @@ func (wr *Writer) writeLoop(ctx context.Context) error {
 	for {
 		select {
 		case <-ctx.Done():
-			return wr.onDone(ctx)
-		case msgs := <-wr.messages:
+			return ctx.Err()
+		case msgs, ok := <-wr.messages:
+			if !ok {
+				return nil
+			}
+			metrics.MessagesChannelDepth.Set(float64(len(wr.messages)))
 			for _, msg := range msgs {
-				beefyInfo, ok := msg.(parachain.BeefyCommitmentInfo)
-				if !ok {
-					return fmt.Errorf("Invalid message")
+				contract, handler, err := wr.routeFor(msg)
+				if err != nil {
+					return err
+				}
+
+				select {
+				case wr.inFlight <- struct{}{}:
+				case <-ctx.Done():
+					return ctx.Err()
 				}
 
-				switch beefyInfo.Status {
-				case parachain.CommitmentWitnessed:
-					err := wr.WriteNewSignatureCommitment(ctx, beefyInfo)
-					if err != nil {
-						wr.log.WithError(err).Error("Error submitting message to ethereum")
-					}
-				// TODO: will these cases even be hit? it's in a different channel.
-				case parachain.InitialVerificationTxSent, parachain.InitialVerificationTxConfirmed:
-					continue // Ethereum listener is responsible for checking tx confirmation
-				case parachain.ReadyToComplete:
-					err := wr.WriteCompleteSignatureCommitment(ctx, beefyInfo)
-					if err != nil {
-						wr.log.WithError(err).Error("Error submitting message to ethereum")
-					}
-				default:
-					wr.log.Info("Invalid beefy commitment status")
+				if err := handler(ctx, contract, msg); err != nil {
+					wr.log.WithError(err).Error("Error submitting message to ethereum")
 				}
+
+				<-wr.inFlight
 			}
 		}
 	}
 }
 
+// handleBeefyMessage is the MessageHandler registered for parachain.BeefyCommitmentInfo
+// messages. It routes to the LightClientBridge contract based on the
+// commitment's stage in the BEEFY verification protocol.
+func (wr *Writer) handleBeefyMessage(ctx context.Context, contract interface{}, msg chain.Message) error {
+	bridge, ok := contract.(*lightclientbridge.Contract)
+	if !ok {
+		return fmt.Errorf("Unexpected contract type for %s", LightClientBridgeContractID)
+	}
+
+	beefyInfo, ok := msg.(parachain.BeefyCommitmentInfo)
+	if !ok {
+		return fmt.Errorf("Invalid message")
+	}
+
+	switch beefyInfo.Status {
+	case parachain.CommitmentWitnessed:
+		return wr.WriteNewSignatureCommitment(ctx, bridge, beefyInfo)
+	// TODO: will these cases even be hit? it's in a different channel.
+	case parachain.InitialVerificationTxSent, parachain.InitialVerificationTxConfirmed:
+		// Ethereum listener is responsible for checking tx confirmation
+		return nil
+	case parachain.ReadyToComplete:
+		if err := wr.reconciler.EnsureContinuity(ctx, beefyInfo); err != nil {
+			return fmt.Errorf("ensuring commitment continuity: %w", err)
+		}
+		return wr.WriteCompleteSignatureCommitment(ctx, bridge, beefyInfo)
+	default:
+		wr.log.Info("Invalid beefy commitment status")
+		return nil
+	}
+}
+
+// signerFn adapts wr.signer to the bind.SignerFn shape expected by
+// bind.TransactOpts, attaching EIP-155/EIP-1559 replay protection for wr.chainID.
 func (wr *Writer) signerFn(_ common.Address, tx *types.Transaction) (*types.Transaction, error) {
-	signedTx, err := types.SignTx(tx, types.HomesteadSigner{}, wr.conn.GetKeyPair().PrivateKey())
+	return wr.signer.SignTx(context.Background(), tx, wr.chainID)
+}
+
+// submit reserves a nonce for the writer's sender, builds a transaction via
+// build, and retries with a bumped gas price on wr.pool.RetryBackoff if the
+// transaction is not mined in time.
+func (wr *Writer) submit(ctx context.Context, stage string, build func(opts *bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	timer := prometheus.NewTimer(metrics.SubmissionSeconds.WithLabelValues(stage))
+	defer timer.ObserveDuration()
+
+	sender := wr.signer.Address()
+
+	nonce, err := wr.nonces.Reserve(ctx, sender)
 	if err != nil {
 		return nil, err
 	}
-	return signedTx, nil
+
+	gasTipCap := new(big.Int).Set(wr.pool.GasTipCap)
+	gasFeeCap := new(big.Int).Set(wr.pool.GasFeeCap)
+
+	for {
+		options := bind.TransactOpts{
+			From:      sender,
+			Signer:    wr.signerFn,
+			Context:   ctx,
+			Nonce:     new(big.Int).SetUint64(nonce),
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+			GasLimit:  5000000, // TODO: reasonable gas limit
+		}
+
+		tx, err := build(&options)
+		if err != nil {
+			wr.nonces.Release(sender, nonce)
+			return nil, err
+		}
+
+		receiptCtx, cancel := context.WithTimeout(ctx, wr.pool.RetryBackoff)
+		receipt, err := bind.WaitMined(receiptCtx, wr.conn.GetClient(), tx)
+		cancel()
+		if err == nil {
+			if receipt.Status == types.ReceiptStatusFailed {
+				metrics.TxReverted.WithLabelValues(stage).Inc()
+				return nil, fmt.Errorf("transaction %s reverted", tx.Hash().Hex())
+			}
+			metrics.CommitmentsSubmitted.WithLabelValues(stage).Inc()
+			return tx, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		wr.log.WithFields(logrus.Fields{
+			"txHash": tx.Hash().Hex(),
+			"nonce":  nonce,
+		}).Warn("Transaction not mined within backoff, resubmitting with higher gas price")
+
+		gasTipCap = new(big.Int).Mul(gasTipCap, big.NewInt(2))
+		gasFeeCap = new(big.Int).Mul(gasFeeCap, big.NewInt(2))
+	}
 }
 
-func (wr *Writer) WriteNewSignatureCommitment(ctx context.Context, beefyInfo parachain.BeefyCommitmentInfo) error {
+func (wr *Writer) WriteNewSignatureCommitment(ctx context.Context, contract *lightclientbridge.Contract, beefyInfo parachain.BeefyCommitmentInfo) error {
 	wr.log.Info("Parachain writer received msg")
 
 	msg, err := beefyInfo.BuildNewSignatureCommitmentMessage()
@@ -119,29 +369,21 @@ func (wr *Writer) WriteNewSignatureCommitment(ctx context.Context, beefyInfo par
 		return err
 	}
 
-	contract := wr.contracts[LightClientBridgeContractID] // TODO: don't hardcode this
-	if contract == nil {
-		return fmt.Errorf("Unknown contract")
-	}
-
-	options := bind.TransactOpts{
-		From:     wr.conn.GetKeyPair().CommonAddress(),
-		Signer:   wr.signerFn,
-		Context:  ctx,
-		GasLimit: 5000000, // TODO: reasonable gas limit
-	}
-
-	tx, err := contract.NewSignatureCommitment(&options, msg.Payload,
-		msg.ValidatorClaimsBitfield, msg.ValidatorSignatureCommitment,
-		msg.ValidatorPublicKey, msg.ValidatorPublicKeyMerkleProof)
+	tx, err := wr.submit(ctx, "new_signature_commitment", func(options *bind.TransactOpts) (*types.Transaction, error) {
+		return contract.NewSignatureCommitment(options, msg.Payload,
+			msg.ValidatorClaimsBitfield, msg.ValidatorSignatureCommitment,
+			msg.ValidatorPublicKey, msg.ValidatorPublicKeyMerkleProof)
+	})
 	if err != nil {
 		wr.log.WithError(err).Error("Failed to submit transaction")
+		wr.emit(WriterEvent{Kind: EventCommitmentReverted, BlockNumber: beefyInfo.BlockNumber, Err: err})
 		return err
 	}
 
 	wr.log.WithFields(logrus.Fields{
 		"txHash": tx.Hash().Hex(),
 	}).Info("New Signature Commitment transaction submitted")
+	wr.emit(WriterEvent{Kind: EventNewCommitmentSubmitted, BlockNumber: beefyInfo.BlockNumber, TxHash: tx.Hash()})
 
 	beefyInfo.Status = parachain.InitialVerificationTxSent
 	beefyInfo.InitialVerificationTxHash = tx.Hash()
@@ -151,7 +393,7 @@ func (wr *Writer) WriteNewSignatureCommitment(ctx context.Context, beefyInfo par
 }
 
 // WriteCompleteSignatureCommitment sends a CompleteSignatureCommitment tx to the LightClientBridge contract
-func (wr *Writer) WriteCompleteSignatureCommitment(ctx context.Context, beefyInfo parachain.BeefyCommitmentInfo) error {
+func (wr *Writer) WriteCompleteSignatureCommitment(ctx context.Context, contract *lightclientbridge.Contract, beefyInfo parachain.BeefyCommitmentInfo) error {
 	wr.log.Info("Parachain writer received msg")
 
 	msg, err := beefyInfo.BuildCompleteSignatureCommitmentMessage()
@@ -159,29 +401,47 @@ func (wr *Writer) WriteCompleteSignatureCommitment(ctx context.Context, beefyInf
 		return err
 	}
 
-	contract := wr.contracts[LightClientBridgeContractID] // TODO: don't hardcode this
-	if contract == nil {
-		return fmt.Errorf("Unknown contract")
-	}
-
-	options := bind.TransactOpts{
-		From:     wr.conn.GetKeyPair().CommonAddress(),
-		Signer:   wr.signerFn,
-		Context:  ctx,
-		GasLimit: 5000000, // TODO: reasonable gas limit
+	// TODO: the LightClientBridge contract has no overload that accepts a
+	// drand round/signature for on-chain verification yet - that requires a
+	// Solidity change and a regenerated binding. Until that lands, a
+	// drand-enabled deployment gets the unbiased subset positions but submits
+	// them through the existing CompleteSignatureCommitment call, same as the
+	// non-drand path; the drand round and BLS signature are derived but not
+	// yet submitted as calldata.
+	if wr.drand != nil {
+		var subset DrandSubset
+		subset, err = wr.drand.Select(ctx, beefyInfo.BlockTimestamp, common.BytesToHash(msg.Payload),
+			len(msg.RandomValidatorAddresses), len(msg.RandomSignatureBitfieldPositions))
+		if err != nil {
+			// Fail closed: a drand-enabled deployment must not fall back to
+			// the contract's own (biasable) randomness.
+			return fmt.Errorf("deriving drand random subset: %w", err)
+		}
+		msg.RandomSignatureBitfieldPositions = subset.Positions
 	}
 
-	tx, err := contract.CompleteSignatureCommitment(&options, msg.ID, msg.Payload, msg.RandomSignatureCommitments,
-		msg.RandomSignatureBitfieldPositions, msg.RandomValidatorAddresses, msg.RandomPublicKeyMerkleProofs)
-
+	tx, err := wr.submit(ctx, "complete_signature_commitment", func(options *bind.TransactOpts) (*types.Transaction, error) {
+		return contract.CompleteSignatureCommitment(options, msg.ID, msg.Payload, msg.RandomSignatureCommitments,
+			msg.RandomSignatureBitfieldPositions, msg.RandomValidatorAddresses, msg.RandomPublicKeyMerkleProofs)
+	})
 	if err != nil {
 		wr.log.WithError(err).Error("Failed to submit transaction")
+		wr.emit(WriterEvent{Kind: EventCommitmentReverted, BlockNumber: beefyInfo.BlockNumber, Err: err})
 		return err
 	}
 
 	wr.log.WithFields(logrus.Fields{
 		"txHash": tx.Hash().Hex(),
 	}).Info("Complete Signature Commitment transaction submitted")
+	wr.emit(WriterEvent{Kind: EventCommitmentCompleteSubmitted, BlockNumber: beefyInfo.BlockNumber, TxHash: tx.Hash()})
+
+	err = wr.cache.Put(CommitmentRecord{
+		BlockNumber:    beefyInfo.BlockNumber,
+		ValidatorSetID: beefyInfo.ValidatorSetID,
+	})
+	if err != nil {
+		wr.log.WithError(err).Warn("Failed to record commitment in cache")
+	}
 
 	return nil
-}
\ No newline at end of file
+}